@@ -0,0 +1,147 @@
+package impact
+
+import "math"
+
+// minSegmentLength is the fewest points a segment may contain.  Without this
+// floor, a one-point segment has zero sample variance and so an arbitrarily
+// favorable cost, which would have PELT split every single point off as its
+// own segment regardless of penalty.
+const minSegmentLength = 2
+
+// DetectChangepoints returns the indices of all changepoints in x using
+// Pruned Exact Linear Time (PELT) segmentation.  penalty controls how
+// aggressively new segments are created; pass <= 0 to use the BIC default of
+// log(n).  Use LabelChangepoints to additionally run DetectImpact across each
+// adjacent pair of segments.
+func DetectChangepoints(x []float64, penalty float64) []int {
+	n := len(x)
+	if n < 2*minSegmentLength {
+		return nil
+	}
+
+	if penalty <= 0 {
+		penalty = math.Log(float64(n))
+	}
+
+	prefixSum, prefixSumSq := prefixSums(x)
+
+	// F[t] is the minimum cost of segmenting x[0:t]; argmin[t] is the last
+	// changepoint of the optimal segmentation ending at t.  F[t] is left at
+	// +Inf while t is too short to hold even one full segment.
+	F := make([]float64, n+1)
+	argmin := make([]int, n+1)
+	for t := range F {
+		F[t] = math.Inf(1)
+	}
+	F[0] = -penalty
+
+	candidates := []int{0}
+
+	for t := 1; t <= n; t++ {
+		best := math.Inf(1)
+		bestS := 0
+		for _, s := range candidates {
+			if t-s < minSegmentLength {
+				continue
+			}
+
+			cost := F[s] + segmentCost(prefixSum, prefixSumSq, s, t) + penalty
+			if cost < best {
+				best = cost
+				bestS = s
+			}
+		}
+		F[t] = best
+		argmin[t] = bestS
+
+		// prune any s that can never be optimal for a future t'; candidates
+		// too young to have produced a feasible segment yet are kept as-is
+		next := make([]int, 0, len(candidates)+1)
+		for _, s := range candidates {
+			if t-s < minSegmentLength {
+				next = append(next, s)
+			} else if F[s]+segmentCost(prefixSum, prefixSumSq, s, t) < F[t] {
+				next = append(next, s)
+			}
+		}
+		candidates = append(next, t)
+	}
+
+	var points []int
+	for t := n; t > 0; {
+		s := argmin[t]
+		if s > 0 {
+			points = append([]int{s}, points...)
+		}
+		t = s
+	}
+
+	return points
+}
+
+// ChangepointSegment reports the direction and significance of the shift at
+// one changepoint produced by DetectChangepoints.
+type ChangepointSegment struct {
+	Index    int
+	PValue   float64
+	Operator Operator
+}
+
+// LabelChangepoints applies DetectImpact to each adjacent pair of segments
+// implied by changepoints, reporting the direction and p-value of the shift
+// at every changepoint.
+func LabelChangepoints(x []float64, changepoints []int, niter int) []ChangepointSegment {
+	n := len(x)
+	bounds := append(append([]int{0}, changepoints...), n)
+
+	labels := make([]ChangepointSegment, 0, len(changepoints))
+	for i := 1; i < len(bounds)-1; i++ {
+		left := x[bounds[i-1]:bounds[i]]
+		right := x[bounds[i]:bounds[i+1]]
+
+		// DetectImpact differences the left segment, so it needs at least
+		// two points there; pairs with fewer than two points on either side
+		// of the changepoint are skipped.
+		if len(left) < 2 || len(right) < 2 {
+			continue
+		}
+
+		p, op := DetectImpact(left, right, niter)
+		labels = append(labels, ChangepointSegment{Index: bounds[i], PValue: p, Operator: op})
+	}
+
+	return labels
+}
+
+// prefixSums returns prefix sums of x and x^2, leading with a 0 so that
+// prefixSum[t]-prefixSum[s] is the sum over x[s:t]
+func prefixSums(x []float64) ([]float64, []float64) {
+	n := len(x)
+	sum := make([]float64, n+1)
+	sumSq := make([]float64, n+1)
+	for i, v := range x {
+		sum[i+1] = sum[i] + v
+		sumSq[i+1] = sumSq[i] + v*v
+	}
+	return sum, sumSq
+}
+
+// segmentCost is the negative Gaussian log-likelihood cost of x[s:t],
+// n*log(sigma_hat^2), computed in O(1) from prefix sums
+func segmentCost(prefixSum, prefixSumSq []float64, s, t int) float64 {
+	n := t - s
+	if n <= 0 {
+		return 0
+	}
+
+	total := prefixSum[t] - prefixSum[s]
+	totalSq := prefixSumSq[t] - prefixSumSq[s]
+
+	m := total / float64(n)
+	variance := totalSq/float64(n) - m*m
+	if variance <= 0 {
+		variance = 1e-8
+	}
+
+	return float64(n) * math.Log(variance)
+}
@@ -0,0 +1,41 @@
+package impact
+
+import "testing"
+
+func TestDetectChangepointsTwoSegments(t *testing.T) {
+	// deterministic two-segment series: constant low plateau then constant
+	// high plateau, with alternating noise so segment variance isn't exactly
+	// zero
+	x := make([]float64, 40)
+	for i := range x {
+		base := 5.0
+		if i >= 20 {
+			base = 15.0
+		}
+		noise := 0.1
+		if i%2 == 1 {
+			noise = -0.1
+		}
+		x[i] = base + noise
+	}
+
+	cps := DetectChangepoints(x, 0)
+
+	if len(cps) != 1 || cps[0] != 20 {
+		t.Fatalf("expected a single changepoint at index 20, got %v", cps)
+	}
+
+	labels := LabelChangepoints(x, cps, 500)
+	if len(labels) != 1 {
+		t.Fatalf("expected a single label, got %v", labels)
+	}
+	if labels[0].Operator != GREATER_THAN {
+		t.Fatalf("expected GREATER_THAN across the level shift, got %v", labels[0].Operator)
+	}
+}
+
+func TestDetectChangepointsTooShortReturnsNil(t *testing.T) {
+	if cps := DetectChangepoints([]float64{1, 2}, 0); cps != nil {
+		t.Fatalf("expected nil for a series too short to hold two segments, got %v", cps)
+	}
+}
@@ -55,13 +55,25 @@ func smoothSeries(x1, x2 []float64) ([]float64, []float64) {
 }
 
 // take random steps in a walk based on the `diff`.  (`diff` is a bunch of steps.)
+// Driven by the shared package-level RNG, guarded by rndMutex for the
+// duration of the walk; see Detector for a deterministic, parallel
+// alternative with its own private RNG per walk.
 func walk(start float64, n int, diff []float64) []float64 {
+	rndMutex.Lock()
+	defer rndMutex.Unlock()
+
+	return walkWith(rnd, start, n, diff)
+}
+
+// walkWith is walk's implementation, parameterised on the RNG so a Detector
+// can drive it with a per-worker generator instead of the shared global one.
+func walkWith(rng *rand.Rand, start float64, n int, diff []float64) []float64 {
 	simulated := make([]float64, n)
 
 	// where we start our walk, simulate each step
 	value := start
 	for i := 0; i < n; i++ {
-		step := sample(diff)
+		step := sampleWith(rng, diff)
 		value += step
 		simulated[i] = value
 	}
@@ -70,40 +82,10 @@ func walk(start float64, n int, diff []float64) []float64 {
 
 // DetectImpact performs Monte Carlo based changepoint detection between two disjoint
 // and adjacent subseries of a larger time series.  Increase `niter` to improve
-// accuracy of the detection.
+// accuracy of the detection.  It is a thin wrapper around DetectImpactWithOptions
+// using the package's historical fixed-width moving average.
 func DetectImpact(x1, x2 []float64, niter int) (float64, Operator) {
-	x1smooth := smooth(x1)
-	x2smooth := smooth(x2)
-
-	n1 := len(x1)
-	n2 := len(x2)
-
-	x1diff := diff(x1smooth)
-
-	// the final destinations of a bunch of random walks
-	simDest := make([]float64, niter)
-	for i := 0; i < niter; i++ {
-		walk := walk(x1smooth[n1-1], n2, x1diff)
-		simDest[i] = walk[n2-1]
-	}
-
-	realDest := x2smooth[n2-1]
-
-	plower := float64(lt(realDest, simDest)) / float64(niter)
-	pupper := float64(gt(realDest, simDest)) / float64(niter)
-
-	p := 1.0
-	op := EQUALS
-
-	if plower < pupper {
-		p = plower
-		op = LESS_THAN
-	} else if pupper < plower {
-		p = pupper
-		op = GREATER_THAN
-	}
-
-	return p, op
+	return DetectImpactWithOptions(x1, x2, niter, Options{})
 }
 
 // count the number of xs greater than x
@@ -130,12 +112,11 @@ func lt(x float64, xs []float64) int {
 	return count
 }
 
-// sample one entry from the vector
-func sample(x []float64) float64 {
-	rndMutex.Lock()
-	defer rndMutex.Unlock()
-
-	index := rnd.Intn(len(x))
+// sampleWith samples one entry from the vector using rng.  Callers that
+// share the package-level rnd must hold rndMutex (see walk); callers with a
+// private *rand.Rand (such as a Detector's workers) need no locking.
+func sampleWith(rng *rand.Rand, x []float64) float64 {
+	index := rng.Intn(len(x))
 	return x[index]
 }
 
@@ -0,0 +1,81 @@
+package impact
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// Detector performs Monte Carlo changepoint detection with a deterministic,
+// seedable random number generator.  Unlike the package-level DetectImpact,
+// which shares a single global RNG across a mutex and therefore serializes
+// every simulated walk, a Detector spreads its niter walks across
+// runtime.GOMAXPROCS(0) worker goroutines.  Each walk i draws from its own
+// *rand.Rand seeded from d.seed and i, so the set of simulated endpoints is
+// identical no matter how many workers happen to run.
+type Detector struct {
+	seed int64
+}
+
+// NewDetector constructs a Detector whose random walks are derived
+// deterministically from seed.
+func NewDetector(seed int64) *Detector {
+	return &Detector{seed: seed}
+}
+
+// DetectImpact behaves like the package-level DetectImpact, but runs its
+// niter random walks in parallel as described on Detector.
+func (d *Detector) DetectImpact(x1, x2 []float64, niter int) (float64, Operator) {
+	return d.DetectImpactWithOptions(x1, x2, niter, Options{})
+}
+
+// DetectImpactWithOptions behaves like the package-level
+// DetectImpactWithOptions, but runs its niter random walks in parallel as
+// described on Detector.
+func (d *Detector) DetectImpactWithOptions(x1, x2 []float64, niter int, opts Options) (float64, Operator) {
+	return detectImpactCore(x1, x2, niter, opts, d.walkEndpoints)
+}
+
+// walkEndpoints runs niter random walks of length n from start based on
+// diffs, split across runtime.GOMAXPROCS(0) worker goroutines.  The workers
+// only divide up the *work*: walk i always draws from rand.NewSource(d.seed +
+// int64(i)), so which worker happens to run it, and how many workers there
+// are in total, cannot change its result.
+func (d *Detector) walkEndpoints(start float64, n int, diffs []float64, niter int) []float64 {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > niter {
+		workers = niter
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	simDest := make([]float64, niter)
+
+	chunk := (niter + workers - 1) / workers
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		lo := w * chunk
+		hi := lo + chunk
+		if hi > niter {
+			hi = niter
+		}
+		if lo >= hi {
+			continue
+		}
+
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				rng := rand.New(rand.NewSource(d.seed + int64(i)))
+				walked := walkWith(rng, start, n, diffs)
+				simDest[i] = walked[n-1]
+			}
+		}(lo, hi)
+	}
+	wg.Wait()
+
+	return simDest
+}
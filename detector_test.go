@@ -0,0 +1,45 @@
+package impact
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestDetectorWalkEndpointsIndependentOfWorkerCount(t *testing.T) {
+	x1 := make([]float64, 30)
+	for i := range x1 {
+		x1[i] = float64(i % 5)
+	}
+	x1diff := diff(x1)
+
+	previous := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(previous)
+
+	var want []float64
+	for _, workers := range []int{1, 2, 3, 4, 8} {
+		runtime.GOMAXPROCS(workers)
+
+		got := NewDetector(42).walkEndpoints(x1[len(x1)-1], 10, x1diff, 23)
+
+		if want == nil {
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("walkEndpoints differs at GOMAXPROCS=%d: got %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestDetectorDetectImpactDeterministic(t *testing.T) {
+	x1 := []float64{1, 2, 1, 2, 1, 2, 1, 2, 1, 2}
+	x2 := []float64{10, 11, 10, 11, 10, 11, 10, 11, 10, 11}
+
+	p1, op1 := NewDetector(7).DetectImpact(x1, x2, 500)
+	p2, op2 := NewDetector(7).DetectImpact(x1, x2, 500)
+
+	if p1 != p2 || op1 != op2 {
+		t.Fatalf("DetectImpact not deterministic for the same seed: (%v, %v) vs (%v, %v)", p1, op1, p2, op2)
+	}
+}
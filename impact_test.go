@@ -0,0 +1,60 @@
+package impact
+
+import "testing"
+
+func TestEstimateImpactFlatSeries(t *testing.T) {
+	x1 := []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+	x2 := []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+
+	result := EstimateImpact(x1, x2, 200)
+
+	for i := range x2 {
+		if result.Predicted[i] != 5 {
+			t.Fatalf("Predicted[%d] = %v, want 5", i, result.Predicted[i])
+		}
+		if result.PredictedLower[i] != 5 || result.PredictedUpper[i] != 5 {
+			t.Fatalf("credible interval at %d = [%v, %v], want [5, 5]", i, result.PredictedLower[i], result.PredictedUpper[i])
+		}
+		if result.PointwiseEffect[i] != 0 {
+			t.Fatalf("PointwiseEffect[%d] = %v, want 0", i, result.PointwiseEffect[i])
+		}
+		if result.CumulativeEffect[i] != 0 {
+			t.Fatalf("CumulativeEffect[%d] = %v, want 0", i, result.CumulativeEffect[i])
+		}
+	}
+}
+
+func TestEstimateImpactConstantShift(t *testing.T) {
+	x1 := []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+	x2 := []float64{10, 10, 10, 10, 10, 10, 10, 10, 10, 10}
+
+	result := EstimateImpact(x1, x2, 200)
+
+	for i := range x2 {
+		if result.PointwiseEffect[i] != 5 {
+			t.Fatalf("PointwiseEffect[%d] = %v, want 5", i, result.PointwiseEffect[i])
+		}
+	}
+
+	if last, want := result.CumulativeEffect[len(x2)-1], 50.0; last != want {
+		t.Fatalf("final CumulativeEffect = %v, want %v", last, want)
+	}
+}
+
+func TestEstimateImpactCredibleIntervalOrdering(t *testing.T) {
+	x1 := []float64{1, 3, 1, 4, 2, 5, 1, 3, 2, 4}
+	x2 := []float64{6, 8, 6, 9, 7, 10, 6, 8, 7, 9}
+
+	result := EstimateImpact(x1, x2, 500)
+
+	for i := range x2 {
+		if !(result.PredictedLower[i] <= result.Predicted[i] && result.Predicted[i] <= result.PredictedUpper[i]) {
+			t.Fatalf("ordering violated at %d: lower=%v predicted=%v upper=%v",
+				i, result.PredictedLower[i], result.Predicted[i], result.PredictedUpper[i])
+		}
+		if !(result.CumulativeEffectLower[i] <= result.CumulativeEffect[i] && result.CumulativeEffect[i] <= result.CumulativeEffectUpper[i]) {
+			t.Fatalf("cumulative ordering violated at %d: lower=%v effect=%v upper=%v",
+				i, result.CumulativeEffectLower[i], result.CumulativeEffect[i], result.CumulativeEffectUpper[i])
+		}
+	}
+}
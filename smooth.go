@@ -0,0 +1,411 @@
+package impact
+
+import "math"
+
+// Options configures DetectImpactWithOptions.  The zero value falls back to
+// the package's historical fixed-width moving average.
+type Options struct {
+	Smoother Smoother
+}
+
+// Smoother transforms a raw series into a smoothed one before it is fed into
+// the change-point detector.
+type Smoother interface {
+	Smooth(x []float64) []float64
+}
+
+// SmootherFunc adapts a plain function to the Smoother interface
+type SmootherFunc func(x []float64) []float64
+
+// Smooth calls f(x)
+func (f SmootherFunc) Smooth(x []float64) []float64 {
+	return f(x)
+}
+
+// resolveSmoother returns opts.Smoother, or the package's historical
+// fixed-width moving average if opts is the zero value.  Every *WithOptions
+// entry point (DetectImpactWithOptions, EstimateImpactWithOptions,
+// DetectImpactTestWithOptions) resolves its Smoother through this helper so
+// the default stays in one place.
+func resolveSmoother(opts Options) Smoother {
+	if opts.Smoother != nil {
+		return opts.Smoother
+	}
+	return MovingAverage(smoother)
+}
+
+// MovingAverage smooths x with a symmetric boxcar mean of the given
+// half-width, matching the package's original fixed smoother = 2 behaviour
+func MovingAverage(width int) Smoother {
+	return SmootherFunc(func(x []float64) []float64 {
+		n := len(x)
+		smoothed := make([]float64, n)
+
+		for index := 0; index < n; index++ {
+			leftmost := index - width
+			if leftmost < 0 {
+				leftmost = 0
+			}
+
+			rightmost := index + width + 1
+			if rightmost > n {
+				rightmost = n
+			}
+
+			smoothed[index] = mean(x[leftmost:rightmost])
+		}
+
+		return smoothed
+	})
+}
+
+// minGaussianSigma floors the standard deviation passed to Gaussian so the
+// kernel's weights never divide by zero
+const minGaussianSigma = 1e-6
+
+// Gaussian smooths x with a Gaussian kernel of the given standard deviation,
+// truncated at +/- 3 sigma.  sigma is floored at minGaussianSigma to avoid a
+// division by zero.
+func Gaussian(sigma float64) Smoother {
+	if sigma < minGaussianSigma {
+		sigma = minGaussianSigma
+	}
+
+	return SmootherFunc(func(x []float64) []float64 {
+		n := len(x)
+		smoothed := make([]float64, n)
+
+		radius := int(math.Ceil(3 * sigma))
+		if radius < 1 {
+			radius = 1
+		}
+
+		weights := make([]float64, 2*radius+1)
+		for i := -radius; i <= radius; i++ {
+			weights[i+radius] = math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		}
+
+		for index := 0; index < n; index++ {
+			value := 0.0
+			usedWeight := 0.0
+			for i := -radius; i <= radius; i++ {
+				j := index + i
+				if j < 0 || j >= n {
+					continue
+				}
+
+				w := weights[i+radius]
+				value += w * x[j]
+				usedWeight += w
+			}
+
+			smoothed[index] = value / usedWeight
+		}
+
+		return smoothed
+	})
+}
+
+// LOESS smooths x with locally weighted polynomial regression of the given
+// degree (0 for a local mean, 1 for a local line), fitting each point from a
+// tricube-weighted neighbourhood spanning bandwidth*len(x) points either side
+func LOESS(bandwidth float64, degree int) Smoother {
+	return SmootherFunc(func(x []float64) []float64 {
+		n := len(x)
+		smoothed := make([]float64, n)
+
+		span := int(bandwidth * float64(n))
+		if span < 1 {
+			span = 1
+		}
+
+		for index := 0; index < n; index++ {
+			leftmost := index - span
+			if leftmost < 0 {
+				leftmost = 0
+			}
+
+			rightmost := index + span + 1
+			if rightmost > n {
+				rightmost = n
+			}
+
+			maxDist := math.Max(float64(index-leftmost), float64(rightmost-1-index))
+			if maxDist == 0 {
+				maxDist = 1
+			}
+
+			var sumW, sumWX, sumWY, sumWXX, sumWXY float64
+			for j := leftmost; j < rightmost; j++ {
+				d := math.Abs(float64(j-index)) / maxDist
+				if d >= 1 {
+					continue
+				}
+
+				w := math.Pow(1-math.Pow(d, 3), 3)
+				xv := float64(j - index)
+				sumW += w
+				sumWX += w * xv
+				sumWY += w * x[j]
+				sumWXX += w * xv * xv
+				sumWXY += w * xv * x[j]
+			}
+
+			denom := sumW*sumWXX - sumWX*sumWX
+			if degree <= 0 || denom == 0 {
+				smoothed[index] = sumWY / sumW
+				continue
+			}
+
+			// weighted linear regression, evaluated at the point itself (xv=0)
+			smoothed[index] = (sumWY*sumWXX - sumWX*sumWXY) / denom
+		}
+
+		return smoothed
+	})
+}
+
+// HoltWinters smooths x with triple exponential smoothing (level, trend and
+// additive seasonality of the given period), returning the one-step-ahead
+// fitted values.  alpha, beta and gamma must lie in [0, 1]; use
+// HoltWintersAuto to have them chosen automatically instead
+func HoltWinters(alpha, beta, gamma float64, period int) Smoother {
+	return SmootherFunc(func(x []float64) []float64 {
+		return holtWinters(x, alpha, beta, gamma, period)
+	})
+}
+
+// HoltWintersAuto smooths x with triple exponential smoothing, fitting alpha,
+// beta and gamma automatically by minimising SSE against x instead of taking
+// them as arguments
+func HoltWintersAuto(period int) Smoother {
+	return SmootherFunc(func(x []float64) []float64 {
+		alpha, beta, gamma := fitHoltWinters(x, period)
+		return holtWinters(x, alpha, beta, gamma, period)
+	})
+}
+
+// holtWinters computes one-step-ahead triple exponential smoothing fitted
+// values for x.  When x is too short to seed two full seasonal cycles (or
+// period < 1) it falls back to plain double exponential smoothing (level and
+// trend only)
+func holtWinters(x []float64, alpha, beta, gamma float64, period int) []float64 {
+	n := len(x)
+	fitted := make([]float64, n)
+	if n == 0 {
+		return fitted
+	}
+
+	if period < 1 || n < 2*period {
+		level := x[0]
+		trend := 0.0
+		for i := 0; i < n; i++ {
+			fitted[i] = level + trend
+			prevLevel := level
+			level = alpha*x[i] + (1-alpha)*(level+trend)
+			trend = beta*(level-prevLevel) + (1-beta)*trend
+		}
+		return fitted
+	}
+
+	level := mean(x[0:period])
+	trend := (mean(x[period:2*period]) - level) / float64(period)
+
+	seasonal := make([]float64, period)
+	for i := 0; i < period; i++ {
+		seasonal[i] = x[i] - level
+	}
+
+	for i := 0; i < n; i++ {
+		s := seasonal[i%period]
+		fitted[i] = level + trend + s
+
+		prevLevel := level
+		level = alpha*(x[i]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		seasonal[i%period] = gamma*(x[i]-level) + (1-gamma)*s
+	}
+
+	return fitted
+}
+
+// fitHoltWinters chooses alpha, beta and gamma in [0, 1] that minimise the sum
+// of squared one-step-ahead errors of holtWinters against x, using the
+// Nelder-Mead simplex method.  When x is too short to seed two full periods
+// all three parameters default to 0.5.
+func fitHoltWinters(x []float64, period int) (alpha, beta, gamma float64) {
+	if period < 1 || len(x) < 2*period {
+		return 0.5, 0.5, 0.5
+	}
+
+	sse := func(p [3]float64) float64 {
+		a, b, g := clamp01(p[0]), clamp01(p[1]), clamp01(p[2])
+		fitted := holtWinters(x, a, b, g, period)
+
+		total := 0.0
+		for i, v := range fitted {
+			e := x[i] - v
+			total += e * e
+		}
+		return total
+	}
+
+	best := nelderMead(sse, [3]float64{0.3, 0.1, 0.1})
+	return clamp01(best[0]), clamp01(best[1]), clamp01(best[2])
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// nelderMead minimises f over a 3-dimensional simplex seeded near start,
+// returning the best point found after a fixed number of iterations
+func nelderMead(f func([3]float64) float64, start [3]float64) [3]float64 {
+	const (
+		reflection  = 1.0
+		contraction = 0.5
+		expansion   = 2.0
+		iterations  = 200
+	)
+
+	simplex := [4][3]float64{start, start, start, start}
+	for i := 0; i < 3; i++ {
+		simplex[i+1][i] += 0.1
+	}
+
+	values := make([]float64, 4)
+	for i, p := range simplex {
+		values[i] = f(p)
+	}
+
+	for iter := 0; iter < iterations; iter++ {
+		for i := 0; i < 4; i++ {
+			for j := i + 1; j < 4; j++ {
+				if values[j] < values[i] {
+					values[i], values[j] = values[j], values[i]
+					simplex[i], simplex[j] = simplex[j], simplex[i]
+				}
+			}
+		}
+
+		worst := simplex[3]
+		worstValue := values[3]
+
+		var centroid [3]float64
+		for i := 0; i < 3; i++ {
+			for d := 0; d < 3; d++ {
+				centroid[d] += simplex[i][d]
+			}
+		}
+		for d := 0; d < 3; d++ {
+			centroid[d] /= 3
+		}
+
+		reflected := reflectPoint(centroid, worst, reflection)
+		reflectedValue := f(reflected)
+
+		switch {
+		case reflectedValue < values[0]:
+			expanded := reflectPoint(centroid, worst, expansion)
+			expandedValue := f(expanded)
+			if expandedValue < reflectedValue {
+				simplex[3], values[3] = expanded, expandedValue
+			} else {
+				simplex[3], values[3] = reflected, reflectedValue
+			}
+		case reflectedValue < values[2]:
+			simplex[3], values[3] = reflected, reflectedValue
+		default:
+			contracted := reflectPoint(centroid, worst, -contraction)
+			contractedValue := f(contracted)
+			if contractedValue < worstValue {
+				simplex[3], values[3] = contracted, contractedValue
+			} else {
+				for i := 1; i < 4; i++ {
+					for d := 0; d < 3; d++ {
+						simplex[i][d] = simplex[0][d] + contraction*(simplex[i][d]-simplex[0][d])
+					}
+					values[i] = f(simplex[i])
+				}
+			}
+		}
+	}
+
+	best := 0
+	for i := 1; i < 4; i++ {
+		if values[i] < values[best] {
+			best = i
+		}
+	}
+	return simplex[best]
+}
+
+// reflectPoint moves point away from pivot by a factor of scale
+func reflectPoint(pivot, point [3]float64, scale float64) [3]float64 {
+	var out [3]float64
+	for d := 0; d < 3; d++ {
+		out[d] = pivot[d] + scale*(pivot[d]-point[d])
+	}
+	return out
+}
+
+// DetectImpactWithOptions behaves like DetectImpact but smooths x1 and x2 with
+// opts.Smoother instead of the fixed-width moving average.  A zero-value
+// Options falls back to the original MovingAverage(smoother) behaviour.
+func DetectImpactWithOptions(x1, x2 []float64, niter int, opts Options) (float64, Operator) {
+	return detectImpactCore(x1, x2, niter, opts, walkEndpointsSerial)
+}
+
+// walkEndpointsSerial simulates niter random walks of length n from start
+// based on diffs, one after another using the package-level RNG.  It is the
+// endpoint generator behind the package-level DetectImpact functions; see
+// Detector for a parallel, seedable alternative.
+func walkEndpointsSerial(start float64, n int, diffs []float64, niter int) []float64 {
+	simDest := make([]float64, niter)
+	for i := 0; i < niter; i++ {
+		w := walk(start, n, diffs)
+		simDest[i] = w[n-1]
+	}
+	return simDest
+}
+
+// detectImpactCore holds the smoothing and p-value/operator computation
+// shared by DetectImpactWithOptions and (*Detector).DetectImpactWithOptions;
+// only how the simulated endpoints are produced differs between them.
+func detectImpactCore(x1, x2 []float64, niter int, opts Options, walkEndpoints func(start float64, n int, diffs []float64, niter int) []float64) (float64, Operator) {
+	s := resolveSmoother(opts)
+
+	x1smooth := s.Smooth(x1)
+	x2smooth := s.Smooth(x2)
+
+	n1 := len(x1)
+	n2 := len(x2)
+
+	x1diff := diff(x1smooth)
+
+	simDest := walkEndpoints(x1smooth[n1-1], n2, x1diff, niter)
+
+	realDest := x2smooth[n2-1]
+
+	plower := float64(lt(realDest, simDest)) / float64(niter)
+	pupper := float64(gt(realDest, simDest)) / float64(niter)
+
+	p := 1.0
+	op := EQUALS
+
+	if plower < pupper {
+		p = plower
+		op = LESS_THAN
+	} else if pupper < plower {
+		p = pupper
+		op = GREATER_THAN
+	}
+
+	return p, op
+}
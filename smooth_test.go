@@ -0,0 +1,73 @@
+package impact
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMovingAverageMatchesFixedSmooth(t *testing.T) {
+	x := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3}
+
+	got := MovingAverage(smoother).Smooth(x)
+	want := smooth(x)
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("MovingAverage(smoother)[%d] = %v, want %v (from smooth())", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLOESSDegreeZeroIsWeightedMean(t *testing.T) {
+	// on a symmetric window around a symmetric series, the tricube weights
+	// are themselves symmetric, so the locally weighted mean must land
+	// exactly back on the center value
+	x := []float64{1, 2, 3, 4, 5}
+
+	got := LOESS(1.0, 0).Smooth(x)
+	if want := 3.0; math.Abs(got[2]-want) > 1e-9 {
+		t.Fatalf("LOESS(1.0, 0)[2] = %v, want %v", got[2], want)
+	}
+}
+
+func TestLOESSDegreeOneFitsALineExactly(t *testing.T) {
+	// weighted linear regression has zero residual on data that is already
+	// perfectly linear, for any positive weights
+	x := make([]float64, 10)
+	for i := range x {
+		x[i] = 2*float64(i) + 1
+	}
+
+	got := LOESS(0.3, 1).Smooth(x)
+	for i := range x {
+		if math.Abs(got[i]-x[i]) > 1e-6 {
+			t.Fatalf("LOESS(0.3, 1)[%d] = %v, want %v", i, got[i], x[i])
+		}
+	}
+}
+
+func TestHoltWintersAutoRecoversCleanSeasonalSeries(t *testing.T) {
+	// a purely seasonal, trend-free series with no noise is already a fixed
+	// point of the Holt-Winters recurrence, so a correct fit (regardless of
+	// the alpha/beta/gamma Nelder-Mead lands on) should reproduce it almost
+	// exactly
+	const period = 4
+	seasonalPattern := []float64{1, -1, 2, -2}
+
+	x := make([]float64, 6*period)
+	for i := range x {
+		x[i] = 10 + seasonalPattern[i%period]
+	}
+
+	fitted := HoltWintersAuto(period).Smooth(x)
+
+	sse := 0.0
+	for i := range x {
+		e := x[i] - fitted[i]
+		sse += e * e
+	}
+
+	if sse > 1e-6 {
+		t.Fatalf("HoltWintersAuto SSE on a clean seasonal series = %v, want ~0", sse)
+	}
+}
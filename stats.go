@@ -0,0 +1,312 @@
+package impact
+
+import (
+	"math"
+	"sort"
+)
+
+// TestKind selects which two-sample statistical test DetectImpactTest uses
+type TestKind int
+
+const (
+	MannWhitneyU TestKind = iota
+	KolmogorovSmirnov
+	WelchT
+)
+
+// DetectImpactTest performs a rigorous two-sample statistical test between the
+// diff distributions of the two smoothed series, as an alternative to
+// DetectImpact's Monte Carlo p-value.  The returned Operator reports whether
+// x2's diffs tend to be larger, smaller, or indistinguishable from x1's; the
+// pvalue reports how confidently.  It is a thin wrapper around
+// DetectImpactTestWithOptions using the package's historical fixed-width
+// moving average.
+func DetectImpactTest(x1, x2 []float64, test TestKind) (float64, Operator) {
+	return DetectImpactTestWithOptions(x1, x2, test, Options{})
+}
+
+// DetectImpactTestWithOptions behaves like DetectImpactTest but smooths x1 and
+// x2 with opts.Smoother instead of the fixed-width moving average, so any of
+// the Gaussian/LOESS/Holt-Winters kernels can back the two-sample test.  A
+// zero-value Options falls back to the original MovingAverage(smoother)
+// behaviour.
+func DetectImpactTestWithOptions(x1, x2 []float64, test TestKind, opts Options) (float64, Operator) {
+	s := resolveSmoother(opts)
+
+	x1smooth := s.Smooth(x1)
+	x2smooth := s.Smooth(x2)
+
+	d1 := diff(x1smooth)
+	d2 := diff(x2smooth)
+
+	var pvalue float64
+	switch test {
+	case KolmogorovSmirnov:
+		pvalue = ksTest(d1, d2)
+	case WelchT:
+		pvalue = welchTTest(d1, d2)
+	default:
+		pvalue = mannWhitneyUTest(d1, d2)
+	}
+
+	op := EQUALS
+	if mean(d2) > mean(d1) {
+		op = GREATER_THAN
+	} else if mean(d2) < mean(d1) {
+		op = LESS_THAN
+	}
+
+	return pvalue, op
+}
+
+// mannWhitneyUTest computes the two-sided Mann-Whitney U p-value between a and
+// b, using the normal approximation (appropriate for n>20 per side) with a
+// tie correction.
+func mannWhitneyUTest(a, b []float64) float64 {
+	n1 := len(a)
+	n2 := len(b)
+
+	pooled := make([]float64, 0, n1+n2)
+	pooled = append(pooled, a...)
+	pooled = append(pooled, b...)
+
+	ranks, tieCorrection := rank(pooled)
+
+	r1 := 0.0
+	for i := 0; i < n1; i++ {
+		r1 += ranks[i]
+	}
+
+	u1 := r1 - float64(n1*(n1+1))/2
+	u2 := float64(n1*n2) - u1
+	u := math.Min(u1, u2)
+
+	nTotal := float64(n1 + n2)
+	meanU := float64(n1*n2) / 2
+	varU := float64(n1*n2) * (nTotal + 1 - tieCorrection/(nTotal*(nTotal-1))) / 12
+
+	if varU <= 0 {
+		return 1.0
+	}
+
+	z := (u - meanU) / math.Sqrt(varU)
+	return 2 * (1 - normalCDF(math.Abs(z)))
+}
+
+// rank assigns average ranks (1-based) to x, returning the ranks alongside
+// the tie correction term sum(t^3 - t) used by the normal approximation
+func rank(x []float64) ([]float64, float64) {
+	n := len(x)
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool { return x[idx[i]] < x[idx[j]] })
+
+	ranks := make([]float64, n)
+	tieCorrection := 0.0
+
+	i := 0
+	for i < n {
+		j := i
+		for j < n && x[idx[j]] == x[idx[i]] {
+			j++
+		}
+
+		avgRank := float64(i+j+1) / 2 // 1-based average of ranks i+1..j
+		for k := i; k < j; k++ {
+			ranks[idx[k]] = avgRank
+		}
+
+		t := float64(j - i)
+		tieCorrection += t*t*t - t
+
+		i = j
+	}
+
+	return ranks, tieCorrection
+}
+
+// normalCDF evaluates the standard normal CDF at z
+func normalCDF(z float64) float64 {
+	return 0.5 * (1 + math.Erf(z/math.Sqrt2))
+}
+
+// ksTest computes the two-sample Kolmogorov-Smirnov p-value between a and b
+func ksTest(a, b []float64) float64 {
+	sa := append([]float64(nil), a...)
+	sb := append([]float64(nil), b...)
+	sort.Float64s(sa)
+	sort.Float64s(sb)
+
+	merged := append(append([]float64(nil), sa...), sb...)
+	sort.Float64s(merged)
+
+	d := 0.0
+	for _, x := range merged {
+		if diff := math.Abs(ecdf(sa, x) - ecdf(sb, x)); diff > d {
+			d = diff
+		}
+	}
+
+	n1 := float64(len(a))
+	n2 := float64(len(b))
+	ne := n1 * n2 / (n1 + n2)
+
+	lambda := (math.Sqrt(ne) + 0.12 + 0.11/math.Sqrt(ne)) * d
+	return ksQ(lambda)
+}
+
+// ecdf returns the fraction of sorted values <= x
+func ecdf(sorted []float64, x float64) float64 {
+	count := sort.Search(len(sorted), func(i int) bool { return sorted[i] > x })
+	return float64(count) / float64(len(sorted))
+}
+
+// ksQ evaluates the Kolmogorov distribution's upper tail Q(lambda), used to
+// convert a KS statistic into a p-value
+func ksQ(lambda float64) float64 {
+	if lambda < 0.2 {
+		return 1.0
+	}
+
+	sum := 0.0
+	for k := 1; k <= 100; k++ {
+		term := math.Exp(-2 * float64(k*k) * lambda * lambda)
+		if k%2 == 1 {
+			sum += term
+		} else {
+			sum -= term
+		}
+	}
+
+	q := 2 * sum
+	if q < 0 {
+		return 0
+	}
+	if q > 1 {
+		return 1
+	}
+	return q
+}
+
+// welchTTest computes Welch's two-sample t-test p-value between a and b,
+// using the Satterthwaite approximation for degrees of freedom
+func welchTTest(a, b []float64) float64 {
+	n1 := float64(len(a))
+	n2 := float64(len(b))
+
+	m1 := mean(a)
+	m2 := mean(b)
+
+	v1 := variance(a, m1)
+	v2 := variance(b, m2)
+
+	se := math.Sqrt(v1/n1 + v2/n2)
+	if se == 0 {
+		return 1.0
+	}
+
+	t := (m1 - m2) / se
+	df := math.Pow(v1/n1+v2/n2, 2) / (math.Pow(v1/n1, 2)/(n1-1) + math.Pow(v2/n2, 2)/(n2-1))
+
+	return 2 * (1 - studentTCDF(math.Abs(t), df))
+}
+
+// variance computes the sample variance of x around its mean m
+func variance(x []float64, m float64) float64 {
+	total := 0.0
+	for _, v := range x {
+		d := v - m
+		total += d * d
+	}
+	return total / float64(len(x)-1)
+}
+
+// studentTCDF approximates the CDF of Student's t distribution with df
+// degrees of freedom at t, via the regularized incomplete beta function
+func studentTCDF(t, df float64) float64 {
+	x := df / (df + t*t)
+	ib := betaInc(df/2, 0.5, x)
+	if t >= 0 {
+		return 1 - 0.5*ib
+	}
+	return 0.5 * ib
+}
+
+// betaInc computes the regularized incomplete beta function I_x(a, b)
+func betaInc(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lbetaA, _ := math.Lgamma(a)
+	lbetaB, _ := math.Lgamma(b)
+	lbetaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lbetaAB - lbetaA - lbetaB + a*math.Log(x) + b*math.Log(1-x))
+
+	if x < (a+1)/(a+b+2) {
+		return front * betaCF(a, b, x) / a
+	}
+	return 1 - front*betaCF(b, a, 1-x)/b
+}
+
+// betaCF evaluates the continued fraction for the incomplete beta function
+// using the modified Lentz algorithm
+func betaCF(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 1e-10
+		tiny          = 1e-30
+	)
+
+	qab := a + b
+	qap := a + 1
+	qam := a - 1
+
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		m2 := float64(2 * m)
+
+		aa := float64(m) * (b - float64(m)) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+
+		aa = -(a + float64(m)) * (qab + float64(m)) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+
+		if math.Abs(del-1) < epsilon {
+			break
+		}
+	}
+
+	return h
+}
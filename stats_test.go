@@ -0,0 +1,57 @@
+package impact
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitneyUTieCorrection(t *testing.T) {
+	// textbook example with one tied group: a = [1,2,2], b = [2,3,4].
+	// Pooled ranks are 1, 3, 3, 3, 5, 6 (the three 2's share rank (2+3+4)/3=3).
+	// R1 = 1+3+3 = 7, U1 = R1 - n1(n1+1)/2 = 7-6 = 1, U = min(1, n1*n2-1) = 1.
+	// tie correction = 3^3-3 = 24; varU = n1*n2*(n+1-24/(n*(n-1)))/12 = 4.65.
+	// z = (1-4.5)/sqrt(4.65) ~= -1.6231, p = 2*(1-Phi(1.6231)) ~= 0.1046.
+	a := []float64{1, 2, 2}
+	b := []float64{2, 3, 4}
+
+	got := mannWhitneyUTest(a, b)
+	want := 0.1046
+
+	if math.Abs(got-want) > 1e-3 {
+		t.Fatalf("mannWhitneyUTest(a, b) = %v, want ~%v", got, want)
+	}
+}
+
+func TestDetectImpactTestIdenticalSeriesHighPValue(t *testing.T) {
+	x1 := []float64{1, 3, 2, 5, 4, 6, 3, 7, 5, 8, 6, 9}
+	x2 := append([]float64(nil), x1...)
+
+	for _, kind := range []TestKind{MannWhitneyU, KolmogorovSmirnov, WelchT} {
+		p, op := DetectImpactTest(x1, x2, kind)
+		if p < 0.9 {
+			t.Errorf("test kind %v: p=%v for identical series, want close to 1", kind, p)
+		}
+		if op != EQUALS {
+			t.Errorf("test kind %v: op=%v for identical series, want EQUALS", kind, op)
+		}
+	}
+}
+
+func TestDetectImpactTestSeparatedSeriesLowPValue(t *testing.T) {
+	x1 := make([]float64, 20)
+	x2 := make([]float64, 20)
+	for i := range x1 {
+		x1[i] = float64(i)      // step of 1 between points
+		x2[i] = float64(i) * 10 // step of 10 between points
+	}
+
+	for _, kind := range []TestKind{MannWhitneyU, KolmogorovSmirnov, WelchT} {
+		p, op := DetectImpactTest(x1, x2, kind)
+		if p > 0.05 {
+			t.Errorf("test kind %v: p=%v for fully separated diff distributions, want close to 0", kind, p)
+		}
+		if op != GREATER_THAN {
+			t.Errorf("test kind %v: op=%v for a steeper x2, want GREATER_THAN", kind, op)
+		}
+	}
+}